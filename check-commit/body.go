@@ -0,0 +1,121 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// trailerLineRe matches a single RFC-5322-style "Token: value" trailer line,
+// e.g. "Signed-off-by: Jane Doe <jane@example.com>". "BREAKING CHANGE" is
+// accepted with a literal space as well as the hyphenated "BREAKING-CHANGE",
+// per the Conventional Commits spec's one exception to tokens never
+// containing whitespace.
+var trailerLineRe = regexp.MustCompile(`^([A-Za-z][A-Za-z0-9-]*|BREAKING CHANGE): .+$`)
+
+// parseTrailers extracts the commit message's trailer block: the last
+// paragraph of the body, provided every one of its lines parses as a
+// "Token: value" trailer. This mirrors git interpret-trailers, which refuses
+// to treat a trailing paragraph as trailers unless it's made up entirely of
+// them.
+func parseTrailers(body string) map[string][]string {
+	trailers := map[string][]string{}
+
+	trimmed := strings.TrimRight(body, "\n")
+	if trimmed == "" {
+		return trailers
+	}
+
+	paragraphs := strings.Split(trimmed, "\n\n")
+	lastParagraph := strings.TrimSpace(paragraphs[len(paragraphs)-1])
+
+	if lastParagraph == "" {
+		return trailers
+	}
+
+	lines := strings.Split(lastParagraph, "\n")
+	for _, l := range lines {
+		if !trailerLineRe.MatchString(l) {
+			return map[string][]string{}
+		}
+	}
+
+	for _, l := range lines {
+		parts := strings.SplitN(l, ": ", 2)
+		trailers[parts[0]] = append(trailers[parts[0]], parts[1])
+	}
+
+	return trailers
+}
+
+var ErrTrailerPolicy = errors.New("commit trailer policy violation")
+
+// CheckTrailers validates body's trailers against RequireSignedOff,
+// RequiredTrailers and IssueRefPattern.
+func (c CommitPolicyConfig) CheckTrailers(body string) error {
+	trailers := parseTrailers(body)
+
+	if c.RequireSignedOff {
+		if _, ok := trailers["Signed-off-by"]; !ok {
+			return newRuleViolation(RuleMissingTrailer,
+				fmt.Errorf("missing required 'Signed-off-by' trailer: %w", ErrTrailerPolicy))
+		}
+	}
+
+	for _, required := range c.RequiredTrailers {
+		if _, ok := trailers[required]; !ok {
+			return newRuleViolation(RuleMissingTrailer,
+				fmt.Errorf("missing required '%s' trailer: %w", required, ErrTrailerPolicy))
+		}
+	}
+
+	if c.IssueRefPattern != "" {
+		re, err := regexp.Compile(c.IssueRefPattern)
+		if err != nil {
+			return fmt.Errorf("invalid IssueRefPattern '%s': %w", c.IssueRefPattern, err)
+		}
+
+		if !re.MatchString(body) {
+			return newRuleViolation(RuleMissingIssueRef,
+				fmt.Errorf("no issue reference matching '%s' found in commit message: %w", c.IssueRefPattern, ErrTrailerPolicy))
+		}
+	}
+
+	return nil
+}
+
+var ErrBodyWrap = errors.New("commit body line too long")
+
+// CheckBody validates that no line of body exceeds BodyWrapAt columns,
+// ignoring quoted ("> ...") and fenced ("```") blocks where reflowing isn't
+// expected. BodyWrapAt <= 0 disables the check.
+func (c CommitPolicyConfig) CheckBody(body string) error {
+	if c.BodyWrapAt <= 0 {
+		return nil
+	}
+
+	inFence := false
+
+	for i, line := range strings.Split(body, "\n") {
+		trimmed := strings.TrimSpace(line)
+
+		if strings.HasPrefix(trimmed, "```") {
+			inFence = !inFence
+
+			continue
+		}
+
+		if inFence || strings.HasPrefix(trimmed, ">") {
+			continue
+		}
+
+		if utf8.RuneCountInString(line) > c.BodyWrapAt {
+			return newRuleViolation(RuleBodyWrap, fmt.Errorf(
+				"body line %d exceeds %d columns: '%s': %w", i+1, c.BodyWrapAt, line, ErrBodyWrap))
+		}
+	}
+
+	return nil
+}
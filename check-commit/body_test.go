@@ -0,0 +1,106 @@
+package main
+
+import "testing"
+
+func TestCheckTrailers(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		policy  CommitPolicyConfig
+		body    string
+		wantErr bool
+	}{
+		{
+			name:   "signed off present",
+			policy: CommitPolicyConfig{RequireSignedOff: true},
+			body:   "explain the change in detail\n\nSigned-off-by: Jane Doe <jane@example.com>",
+		},
+		{
+			name:    "signed off missing",
+			policy:  CommitPolicyConfig{RequireSignedOff: true},
+			body:    "explain the change in detail",
+			wantErr: true,
+		},
+		{
+			name:   "required trailers present",
+			policy: CommitPolicyConfig{RequiredTrailers: []string{"Reviewed-by"}},
+			body:   "explain the change in detail\n\nReviewed-by: John Roe <john@example.com>",
+		},
+		{
+			name:    "required trailers missing",
+			policy:  CommitPolicyConfig{RequiredTrailers: []string{"Reviewed-by"}},
+			body:    "explain the change in detail\n\nSigned-off-by: Jane Doe <jane@example.com>",
+			wantErr: true,
+		},
+		{
+			name:   "issue ref present",
+			policy: CommitPolicyConfig{IssueRefPattern: `GH-\d+`},
+			body:   "fixes GH-42",
+		},
+		{
+			name:    "issue ref missing",
+			policy:  CommitPolicyConfig{IssueRefPattern: `GH-\d+`},
+			body:    "no reference here",
+			wantErr: true,
+		},
+		{
+			name:    "trailing paragraph with non-trailer lines is not a trailer block",
+			policy:  CommitPolicyConfig{RequireSignedOff: true},
+			body:    "explain the change\n\nSigned-off-by: Jane Doe <jane@example.com>\nnot a trailer line",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if err := tt.policy.CheckTrailers(tt.body); (err != nil) != tt.wantErr {
+				t.Errorf("CheckTrailers() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCheckBody(t *testing.T) {
+	t.Parallel()
+
+	policy := CommitPolicyConfig{BodyWrapAt: 20}
+
+	tests := []struct {
+		name    string
+		body    string
+		wantErr bool
+	}{
+		{
+			name: "within wrap width",
+			body: "short line\nanother short one",
+		},
+		{
+			name:    "line too long",
+			body:    "this line is clearly longer than twenty columns",
+			wantErr: true,
+		},
+		{
+			name: "long line inside a fenced block is ignored",
+			body: "see example:\n\n```\nthis line is clearly longer than twenty columns\n```",
+		},
+		{
+			name: "long quoted line is ignored",
+			body: "> this quoted line is clearly longer than twenty columns",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if err := policy.CheckBody(tt.body); (err != nil) != tt.wantErr {
+				t.Errorf("CheckBody() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -3,10 +3,13 @@ package main
 import (
 	"context"
 	"encoding/hex"
+	"encoding/json"
 	"errors"
+	"flag"
 	"fmt"
 	"io/ioutil"
 	"log"
+	"net/http"
 	"os"
 	"path"
 	"regexp"
@@ -15,6 +18,10 @@ import (
 	"unicode"
 	"unicode/utf8"
 
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/storer"
 	"github.com/google/go-github/v35/github"
 
 	"github.com/xanzy/go-gitlab"
@@ -33,10 +40,15 @@ type tagAlternativesT struct {
 }
 
 type CommitPolicyConfig struct {
-	PatchScopes map[string][]string   `yaml:"PatchScopes"`
-	PatchTypes  map[string]patchTypeT `yaml:"PatchTypes"`
-	TagOrder    []tagAlternativesT    `yaml:"TagOrder"`
-	HelpText    string                `yaml:"HelpText"`
+	PatchScopes      map[string][]string   `yaml:"PatchScopes"`
+	PatchTypes       map[string]patchTypeT `yaml:"PatchTypes"`
+	TagOrder         []tagAlternativesT    `yaml:"TagOrder"`
+	HelpText         string                `yaml:"HelpText"`
+	Format           string                `yaml:"Format"`
+	RequireSignedOff bool                  `yaml:"RequireSignedOff"`
+	RequiredTrailers []string              `yaml:"RequiredTrailers"`
+	IssueRefPattern  string                `yaml:"IssueRefPattern"`
+	BodyWrapAt       int                   `yaml:"BodyWrapAt"`
 }
 
 const (
@@ -80,38 +92,178 @@ TagOrder:
 	MINSUBJECTLEN   = 15
 	MAXSUBJECTLEN   = 100
 
-	GITHUB = "Github"
-	GITLAB = "Gitlab"
+	GITHUB    = "Github"
+	GITLAB    = "Gitlab"
+	BITBUCKET = "Bitbucket"
+	GITEA     = "Gitea"
+	LOCAL     = "Local"
+
+	defaultBaseBranch = "main"
+
+	FormatHAProxy      = "haproxy"
+	FormatConventional = "conventional"
+)
+
+// ReleaseImpact is the semantic version bump implied by a commit, derived
+// from its Conventional Commits type, "!" marker and BREAKING CHANGE footer.
+type ReleaseImpact string
+
+const (
+	ReleaseImpactNone  ReleaseImpact = "none"
+	ReleaseImpactPatch ReleaseImpact = "patch"
+	ReleaseImpactMinor ReleaseImpact = "minor"
+	ReleaseImpactMajor ReleaseImpact = "major"
 )
 
+// releaseImpactRank orders impacts so the strongest one found across a list
+// of commits can be kept with a simple comparison.
+var releaseImpactRank = map[ReleaseImpact]int{
+	ReleaseImpactNone:  0,
+	ReleaseImpactPatch: 1,
+	ReleaseImpactMinor: 2,
+	ReleaseImpactMajor: 3,
+}
+
+func maxReleaseImpact(a, b ReleaseImpact) ReleaseImpact {
+	if releaseImpactRank[b] > releaseImpactRank[a] {
+		return b
+	}
+
+	return a
+}
+
 var ErrSubjectMessageFormat = errors.New("invalid subject message format")
 
+// firstMalformedSpacing returns the byte offset of the leading, trailing or
+// doubled space that makes subject disagree with strings.Join(Fields, " ").
+func firstMalformedSpacing(subject string) int {
+	if strings.HasPrefix(subject, " ") {
+		return 0
+	}
+
+	if idx := strings.Index(subject, "  "); idx != -1 {
+		return idx
+	}
+
+	if strings.HasSuffix(subject, " ") {
+		return len(subject) - 1
+	}
+
+	return 0
+}
+
 func checkSubjectText(subject string) error {
 	subjectLen := utf8.RuneCountInString(subject)
 	subjectParts := strings.Fields(subject)
 	subjectPartsLen := len(subjectParts)
 
 	if subject != strings.Join(subjectParts, " ") {
-		return fmt.Errorf(
+		return newRuleViolationAt(RuleDoubleSpaces, firstMalformedSpacing(subject), fmt.Errorf(
 			"malformatted subject string (trailing or double spaces?): '%s' (%w)",
-			subject, ErrSubjectMessageFormat)
+			subject, ErrSubjectMessageFormat))
 	}
 
 	if subjectPartsLen < MINSUBJECTPARTS || subjectPartsLen > MAXSUBJECTPARTS {
-		return fmt.Errorf(
+		return newRuleViolationAt(RuleSubjectLength, 0, fmt.Errorf(
 			"subject word count out of bounds [words %d < %d < %d] '%s': %w",
-			MINSUBJECTPARTS, subjectPartsLen, MAXSUBJECTPARTS, subjectParts, ErrSubjectMessageFormat)
+			MINSUBJECTPARTS, subjectPartsLen, MAXSUBJECTPARTS, subjectParts, ErrSubjectMessageFormat))
 	}
 
 	if subjectLen < MINSUBJECTLEN || subjectLen > MAXSUBJECTLEN {
-		return fmt.Errorf(
+		return newRuleViolationAt(RuleSubjectLength, 0, fmt.Errorf(
 			"subject length out of bounds [len %d < %d < %d] '%s': %w",
-			MINSUBJECTLEN, subjectLen, MAXSUBJECTLEN, subject, ErrSubjectMessageFormat)
+			MINSUBJECTLEN, subjectLen, MAXSUBJECTLEN, subject, ErrSubjectMessageFormat))
 	}
 
 	return nil
 }
 
+// ParsedSubject is the structured form of a Conventional Commits 1.0 header,
+// together with any trailer footers found in the commit body.
+type ParsedSubject struct {
+	Type        string
+	Scope       string
+	Breaking    bool
+	Description string
+	Footers     map[string]string
+}
+
+var conventionalHeaderRe = regexp.MustCompile(
+	`^(?P<type>[a-zA-Z]+)(\((?P<scope>[^)]+)\))?(?P<breaking>!)?: (?P<description>.+)$`)
+
+// parseFooters extracts the BREAKING CHANGE/BREAKING-CHANGE footer from a
+// commit body's trailer block, via the same last-paragraph isolation
+// parseTrailers uses for Signed-off-by et al. A "BREAKING CHANGE: ..." line
+// that merely appears somewhere in the body's prose isn't a footer, so it no
+// longer forces a major ReleaseImpact.
+func parseFooters(body string) map[string]string {
+	footers := map[string]string{}
+
+	trailers := parseTrailers(body)
+	for _, token := range []string{"BREAKING CHANGE", "BREAKING-CHANGE"} {
+		if values := trailers[token]; len(values) > 0 {
+			footers[token] = values[len(values)-1]
+		}
+	}
+
+	return footers
+}
+
+var ErrConventionalFormat = errors.New("invalid conventional commit format")
+
+// parseConventionalSubject parses a Conventional Commits 1.0 header line (and
+// any footers present in the remainder of message) into a ParsedSubject.
+func parseConventionalSubject(message string) (*ParsedSubject, error) {
+	lines := strings.SplitN(message, "\n", 2)
+	header := lines[0]
+
+	body := ""
+	if len(lines) > 1 {
+		body = lines[1]
+	}
+
+	submatches := conventionalHeaderRe.FindStringSubmatch(header)
+	if submatches == nil {
+		return nil, fmt.Errorf("header does not match 'type(scope)!: description': '%s': %w",
+			header, ErrConventionalFormat)
+	}
+
+	parsed := &ParsedSubject{
+		Type:        submatches[conventionalHeaderRe.SubexpIndex("type")],
+		Scope:       submatches[conventionalHeaderRe.SubexpIndex("scope")],
+		Breaking:    submatches[conventionalHeaderRe.SubexpIndex("breaking")] == "!",
+		Description: submatches[conventionalHeaderRe.SubexpIndex("description")],
+		Footers:     parseFooters(body),
+	}
+
+	return parsed, nil
+}
+
+// ReleaseImpact computes the semver bump implied by a parsed conventional
+// commit, following the same rules git-sv derives version bumps from.
+func (p ParsedSubject) ReleaseImpact() ReleaseImpact {
+	if p.Breaking {
+		return ReleaseImpactMajor
+	}
+
+	if _, ok := p.Footers["BREAKING CHANGE"]; ok {
+		return ReleaseImpactMajor
+	}
+
+	if _, ok := p.Footers["BREAKING-CHANGE"]; ok {
+		return ReleaseImpactMajor
+	}
+
+	switch p.Type {
+	case "feat":
+		return ReleaseImpactMinor
+	case "fix", "perf":
+		return ReleaseImpactPatch
+	default:
+		return ReleaseImpactNone
+	}
+}
+
 func (c CommitPolicyConfig) CheckPatchTypes(tag, severity string, patchTypeName string) bool {
 	tagScopeOK := false
 
@@ -144,15 +296,41 @@ func (c CommitPolicyConfig) CheckPatchTypes(tag, severity string, patchTypeName
 
 var ErrTagScope = errors.New("invalid tag and or severity")
 
-func (c CommitPolicyConfig) CheckSubject(rawSubject []byte) error {
+// CheckSubject validates rawSubject against the configured policy, returning
+// the parsed conventional commit (nil under FormatHAProxy) alongside any
+// error so callers can derive a ReleaseImpact without re-parsing.
+func (c CommitPolicyConfig) CheckSubject(rawSubject []byte) (*ParsedSubject, error) {
 	// check for ascii-only before anything else
 	for i := 0; i < len(rawSubject); i++ {
 		if rawSubject[i] > unicode.MaxASCII {
 			log.Printf("non-ascii characters detected in in subject:\n%s", hex.Dump(rawSubject))
 
-			return fmt.Errorf("non-ascii characters in commit subject: %w", ErrTagScope)
+			return nil, newRuleViolationAt(RuleASCIIOnly, i, fmt.Errorf("non-ascii characters in commit subject: %w", ErrTagScope))
 		}
 	}
+
+	if c.Format == FormatConventional {
+		parsed, err := parseConventionalSubject(string(rawSubject))
+		if err != nil {
+			return nil, newRuleViolationAt(RuleConventionalFormat, 0, err)
+		}
+
+		if err := checkSubjectText(parsed.Description); err != nil {
+			// shift the offset from the description to the whole subject
+			var rv *ruleViolation
+			if errors.As(err, &rv) && rv.Location >= 0 {
+				header := strings.SplitN(string(rawSubject), "\n", 2)[0]
+				rv.Location += len(header) - len(parsed.Description)
+			}
+
+			return nil, err
+		}
+
+		return parsed, nil
+	}
+
+	originalLen := len(rawSubject)
+
 	// 5 subgroups, 4. is "/severity", 5. is "severity"
 	r := regexp.MustCompile(`^(?P<match>(?P<tag>[A-Z]+)(\/(?P<severity>[A-Z]+))?: )`)
 
@@ -191,17 +369,28 @@ func (c CommitPolicyConfig) CheckSubject(rawSubject []byte) error {
 		if !tagOK {
 			log.Printf("unable to find match in %s\n", candidates)
 
-			return fmt.Errorf("invalid tag or no tag found, searched through [%s]: %w",
-				strings.Join(tagAlternative.PatchTypes, ", "), ErrTagScope)
+			return nil, newRuleViolationAt(RulePatchType, originalLen-len(rawSubject), fmt.Errorf(
+				"invalid tag or no tag found, searched through [%s]: %w",
+				strings.Join(tagAlternative.PatchTypes, ", "), ErrTagScope))
 		}
 	}
 
 	submatch := r.FindSubmatchIndex(rawSubject)
 	if len(submatch) != 0 { // no match
-		return fmt.Errorf("detected unprocessed tags, %w", ErrTagScope)
+		return nil, newRuleViolationAt(RuleTagOrder, originalLen-len(rawSubject), fmt.Errorf("detected unprocessed tags, %w", ErrTagScope))
 	}
 
-	return checkSubjectText(string(rawSubject))
+	if err := checkSubjectText(string(rawSubject)); err != nil {
+		// shift the offset past the tags consumed above
+		var rv *ruleViolation
+		if errors.As(err, &rv) && rv.Location >= 0 {
+			rv.Location += originalLen - len(rawSubject)
+		}
+
+		return nil, err
+	}
+
+	return nil, nil
 }
 
 func (c CommitPolicyConfig) IsEmpty() bool {
@@ -217,6 +406,9 @@ type gitEnv struct {
 	Token       string
 	ProjectID   string
 	PMRequestID string
+	RepoPath    string
+	Range       string
+	SkipMerges  bool
 }
 
 type gitEnvVars struct {
@@ -229,10 +421,24 @@ type gitEnvVars struct {
 
 var ErrGitEnvironment = errors.New("git environment error")
 
-func readGitEnvironment() (*gitEnv, error) {
+// readGitEnvironment auto-detects which CI provider the tool is running
+// under. When rangeSpec is non-empty, or when none of the known CI
+// environments are detected, it falls back to the LOCAL backend so the tool
+// can run as a pre-commit/pre-push hook or in air-gapped CI without an API
+// token.
+func readGitEnvironment(rangeSpec string) (*gitEnv, error) {
+	if rangeSpec != "" {
+		log.Printf("using explicit commit range '%s'\n", rangeSpec)
+
+		return &gitEnv{EnvName: LOCAL, Range: rangeSpec}, nil
+	}
+
 	knownVars := []gitEnvVars{
 		{GITHUB, "GITHUB_API_URL", "API_TOKEN", "GITHUB_REPOSITORY", "GITHUB_SHA"},
 		{GITLAB, "CI_API_V4_URL", "CI_JOB_TOKEN", "CI_MERGE_REQUEST_PROJECT_ID", "CI_MERGE_REQUEST_ID"},
+		{BITBUCKET, "BITBUCKET_API_URL", "BITBUCKET_TOKEN", "BITBUCKET_PROJECT", "BITBUCKET_PR_ID"},
+		{GITEA, "GITEA_API_URL", "GITEA_TOKEN", "GITEA_REPOSITORY", "GITEA_PR_NUMBER"},
+		{GITEA, "FORGEJO_API_URL", "FORGEJO_TOKEN", "FORGEJO_REPOSITORY", "FORGEJO_PR_NUMBER"},
 	}
 
 	for _, vars := range knownVars {
@@ -255,7 +461,9 @@ func readGitEnvironment() (*gitEnv, error) {
 		}
 	}
 
-	return nil, fmt.Errorf("no suitable git environment variables found: %w", ErrGitEnvironment)
+	log.Printf("no CI environment variables found, falling back to local git history\n")
+
+	return &gitEnv{EnvName: LOCAL}, nil
 }
 
 func LoadCommitPolicy(filename string) (CommitPolicyConfig, error) {
@@ -278,9 +486,12 @@ func LoadCommitPolicy(filename string) (CommitPolicyConfig, error) {
 	return commitPolicy, nil
 }
 
-func getGithubCommitSubjects(token string, repo string, sha string) ([]string, error) {
-	ctx := context.Background()
-
+// getGithubCommits returns the SHA and full message of the commits
+// belonging to the PR the given sha was pushed as part of, or just that
+// commit's own SHA and message when it wasn't pushed as part of a PR. Only
+// the first page of the PR's commit list is read (the API's default page
+// size); commits beyond it are not returned.
+func getGithubCommits(ctx context.Context, token string, repo string, sha string) ([]Commit, error) {
 	ts := oauth2.StaticTokenSource(
 		&oauth2.Token{AccessToken: token},
 	)
@@ -294,19 +505,16 @@ func getGithubCommitSubjects(token string, repo string, sha string) ([]string, e
 		return nil, fmt.Errorf("error fetching prs for commit %s: %w", sha, err)
 	}
 
-	subjects := []string{}
+	commits := []Commit{}
 	if len(prs) > 0 {
 		// Check the latest PR with this commit
 		prNo := prs[0].GetNumber()
-		commits, _, err := githubClient.PullRequests.ListCommits(ctx, repoSlice[0], repoSlice[1], prNo, &github.ListOptions{})
+		prCommits, _, err := githubClient.PullRequests.ListCommits(ctx, repoSlice[0], repoSlice[1], prNo, &github.ListOptions{})
 		if err != nil {
 			return nil, fmt.Errorf("error fetching commits: %w", err)
 		}
-		for _, c := range commits {
-			l := strings.SplitN(c.Commit.GetMessage(), "\n", 2)
-			if len(l) > 0 {
-				subjects = append(subjects, l[0])
-			}
+		for _, c := range prCommits {
+			commits = append(commits, Commit{SHA: c.GetSHA(), Message: c.Commit.GetMessage()})
 		}
 	} else {
 		// no PRs, event was a direct push, check only latest commit
@@ -314,16 +522,16 @@ func getGithubCommitSubjects(token string, repo string, sha string) ([]string, e
 		if err != nil {
 			return nil, fmt.Errorf("error fetching commit %s: %w", sha, err)
 		}
-		l := strings.SplitN(c.Commit.GetMessage(), "\n", 2)
-		if len(l) > 0 {
-			subjects = append(subjects, l[0])
-		}
+		commits = append(commits, Commit{SHA: c.GetSHA(), Message: c.Commit.GetMessage()})
 	}
 
-	return subjects, nil
+	return commits, nil
 }
 
-func gitGitlabCommitSubjects(url string, token string, project string, mr string) ([]string, error) {
+// getGitlabCommits returns the SHA and full message of the commits in the
+// given merge request. Only the first page of the MR's commit list is read
+// (the API's default page size); commits beyond it are not returned.
+func getGitlabCommits(url string, token string, project string, mr string) ([]Commit, error) {
 	gitlabClient, err := gitlab.NewClient(token, gitlab.WithBaseURL(url))
 	if err != nil {
 		log.Fatalf("Failed to create gitlab client: %v", err)
@@ -333,61 +541,440 @@ func gitGitlabCommitSubjects(url string, token string, project string, mr string
 	if err != nil {
 		return nil, fmt.Errorf("invalid merge request id %s", mr)
 	}
-	commits, _, err := gitlabClient.MergeRequests.GetMergeRequestCommits(project, mrID, &gitlab.GetMergeRequestCommitsOptions{})
+	mrCommits, _, err := gitlabClient.MergeRequests.GetMergeRequestCommits(project, mrID, &gitlab.GetMergeRequestCommitsOptions{})
 	if err != nil {
 		return nil, fmt.Errorf("error fetching commits: %w", err)
 	}
 
-	subjects := []string{}
-	for _, c := range commits {
-		l := strings.SplitN(c.Message, "\n", 2)
-		if len(l) > 0 {
-			subjects = append(subjects, l[0])
+	commits := []Commit{}
+	for _, c := range mrCommits {
+		commits = append(commits, Commit{SHA: c.ID, Message: c.Message})
+	}
+
+	return commits, nil
+}
+
+// resolveCommitRange turns a "" or "A..B" range spec into the two commit
+// hashes to walk between. An empty spec defaults B to HEAD and A to
+// origin/<defaultBaseBranch> (overridable via CHECK_COMMIT_BASE_BRANCH), so
+// a plain local checkout without any flags still does something sensible.
+func resolveCommitRange(repo *git.Repository, rangeSpec string) (plumbing.Hash, plumbing.Hash, error) {
+	fromRev, toRev := "", "HEAD"
+
+	if rangeSpec != "" {
+		parts := strings.SplitN(rangeSpec, "..", 2)
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return plumbing.ZeroHash, plumbing.ZeroHash,
+				fmt.Errorf("invalid range '%s', expected 'A..B': %w", rangeSpec, ErrGitEnvironment)
+		}
+
+		fromRev, toRev = parts[0], parts[1]
+	} else {
+		base := os.Getenv("CHECK_COMMIT_BASE_BRANCH")
+		if base == "" {
+			base = defaultBaseBranch
+		}
+
+		fromRev = "origin/" + base
+	}
+
+	from, err := repo.ResolveRevision(plumbing.Revision(fromRev))
+	if err != nil {
+		return plumbing.ZeroHash, plumbing.ZeroHash, fmt.Errorf("error resolving revision '%s': %w", fromRev, err)
+	}
+
+	to, err := repo.ResolveRevision(plumbing.Revision(toRev))
+	if err != nil {
+		return plumbing.ZeroHash, plumbing.ZeroHash, fmt.Errorf("error resolving revision '%s': %w", toRev, err)
+	}
+
+	return *from, *to, nil
+}
+
+// checkAncestry verifies that fromHash is actually an ancestor of toHash
+// before the caller walks the log between them. Without this, a range whose
+// lower bound isn't reachable from its upper bound would never hit the
+// iterator's stop condition and getLocalCommits would silently return the
+// whole history reachable from toHash instead of erroring.
+func checkAncestry(repo *git.Repository, fromHash, toHash plumbing.Hash) error {
+	fromCommit, err := repo.CommitObject(fromHash)
+	if err != nil {
+		return fmt.Errorf("error resolving commit '%s': %w", fromHash, err)
+	}
+
+	toCommit, err := repo.CommitObject(toHash)
+	if err != nil {
+		return fmt.Errorf("error resolving commit '%s': %w", toHash, err)
+	}
+
+	isAncestor, err := fromCommit.IsAncestor(toCommit)
+	if err != nil {
+		return fmt.Errorf("error checking ancestry of '%s'..'%s': %w", fromHash, toHash, err)
+	}
+
+	if !isAncestor {
+		return fmt.Errorf("'%s' is not an ancestor of '%s': %w", fromHash, toHash, ErrGitEnvironment)
+	}
+
+	return nil
+}
+
+// getLocalCommits reads full commit SHAs and messages straight out of the
+// local repository clone at repoPath, walking from the resolved range's
+// upper bound back to (but excluding) its lower bound. This needs no API
+// token and works equally well as a pre-commit/pre-push hook or in
+// air-gapped CI.
+func getLocalCommits(repoPath string, rangeSpec string, skipMerges bool) ([]Commit, error) {
+	repo, err := git.PlainOpen(repoPath)
+	if err != nil {
+		return nil, fmt.Errorf("error opening local repository at '%s': %w", repoPath, err)
+	}
+
+	fromHash, toHash, err := resolveCommitRange(repo, rangeSpec)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkAncestry(repo, fromHash, toHash); err != nil {
+		return nil, err
+	}
+
+	commitIter, err := repo.Log(&git.LogOptions{From: toHash})
+	if err != nil {
+		return nil, fmt.Errorf("error walking commit log: %w", err)
+	}
+	defer commitIter.Close()
+
+	commits := []Commit{}
+
+	err = commitIter.ForEach(func(c *object.Commit) error {
+		if c.Hash == fromHash {
+			return storer.ErrStop
+		}
+
+		if skipMerges && c.NumParents() > 1 {
+			return nil
+		}
+
+		commits = append(commits, Commit{SHA: c.Hash.String(), Message: c.Message})
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error iterating commit log: %w", err)
+	}
+
+	return commits, nil
+}
+
+const bitbucketDefaultAPIURL = "https://api.bitbucket.org/2.0"
+
+type bitbucketCommit struct {
+	Hash    string `json:"hash"`
+	Message string `json:"message"`
+}
+
+type bitbucketCommitsPage struct {
+	Values []bitbucketCommit `json:"values"`
+	Next   string            `json:"next"`
+}
+
+// getBitbucketCommits enumerates the SHA and message of every commit of a
+// Bitbucket Cloud pull request via its 2.0 REST API, following the "next"
+// pagination link of each page. Only the Cloud path layout
+// (/repositories/<workspace>/<repo>/pullrequests/<id>/commits) and its "hash"
+// commit field are understood; Bitbucket Server pull requests are not
+// supported.
+func getBitbucketCommits(ctx context.Context, apiURL string, token string, project string, prID string) ([]Commit, error) {
+	if apiURL == "" {
+		apiURL = bitbucketDefaultAPIURL
+	}
+
+	url := fmt.Sprintf("%s/repositories/%s/pullrequests/%s/commits", apiURL, project, prID)
+
+	commits := []Commit{}
+
+	for url != "" {
+		page, err := fetchBitbucketCommitsPage(ctx, url, token)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, c := range page.Values {
+			commits = append(commits, Commit{SHA: c.Hash, Message: c.Message})
 		}
+
+		url = page.Next
+	}
+
+	return commits, nil
+}
+
+func fetchBitbucketCommitsPage(ctx context.Context, url string, token string) (*bitbucketCommitsPage, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error building bitbucket request: %w", err)
+	}
+
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching bitbucket commits: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("bitbucket api returned status %s for %s", resp.Status, url)
+	}
+
+	var page bitbucketCommitsPage
+	if err := json.NewDecoder(resp.Body).Decode(&page); err != nil {
+		return nil, fmt.Errorf("error decoding bitbucket response: %w", err)
 	}
 
-	return subjects, nil
+	return &page, nil
+}
+
+const giteaDefaultAPIURL = "https://gitea.com/api/v1"
+
+type giteaCommit struct {
+	SHA    string `json:"sha"`
+	Commit struct {
+		Message string `json:"message"`
+	} `json:"commit"`
 }
 
-func getCommitSubjects(repoEnv *gitEnv) ([]string, error) {
-	if repoEnv.EnvName == GITHUB {
-		return getGithubCommitSubjects(repoEnv.Token, repoEnv.ProjectID, repoEnv.PMRequestID)
-	} else if repoEnv.EnvName == GITLAB {
-		return gitGitlabCommitSubjects(repoEnv.URL, repoEnv.Token, repoEnv.ProjectID, repoEnv.PMRequestID)
+// getGiteaCommits enumerates the SHA and message of the commits of a Gitea
+// or Forgejo pull request via the shared Gitea REST API the two forges
+// implement. It makes a single request and reads only the first page of
+// results; commits beyond it are not returned.
+func getGiteaCommits(ctx context.Context, apiURL string, token string, repo string, prIndex string) ([]Commit, error) {
+	if apiURL == "" {
+		apiURL = giteaDefaultAPIURL
+	}
+
+	url := fmt.Sprintf("%s/repos/%s/pulls/%s/commits", apiURL, repo, prIndex)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error building gitea request: %w", err)
+	}
+
+	if token != "" {
+		req.Header.Set("Authorization", "token "+token)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching gitea commits: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("gitea api returned status %s for %s", resp.Status, url)
 	}
-	return nil, fmt.Errorf("unrecognized git environment %s", repoEnv.EnvName)
+
+	var giteaCommits []giteaCommit
+	if err := json.NewDecoder(resp.Body).Decode(&giteaCommits); err != nil {
+		return nil, fmt.Errorf("error decoding gitea response: %w", err)
+	}
+
+	commits := []Commit{}
+	for _, c := range giteaCommits {
+		commits = append(commits, Commit{SHA: c.SHA, Message: c.Commit.Message})
+	}
+
+	return commits, nil
+}
+
+// Commit is a single commit under review, carrying both its SHA (so
+// findings against it can be traced back to the originating commit) and its
+// full message.
+type Commit struct {
+	SHA     string
+	Message string
+}
+
+// Provider fetches the commits relevant to the change currently under
+// review, abstracting over the forge the action happens to run on.
+type Provider interface {
+	FetchCommits(ctx context.Context) ([]Commit, error)
+}
+
+type githubProvider struct {
+	Token string
+	Repo  string
+	SHA   string
+}
+
+func (p githubProvider) FetchCommits(ctx context.Context) ([]Commit, error) {
+	return getGithubCommits(ctx, p.Token, p.Repo, p.SHA)
+}
+
+type gitlabProvider struct {
+	URL     string
+	Token   string
+	Project string
+	MR      string
+}
+
+func (p gitlabProvider) FetchCommits(_ context.Context) ([]Commit, error) {
+	return getGitlabCommits(p.URL, p.Token, p.Project, p.MR)
+}
+
+type bitbucketProvider struct {
+	APIURL  string
+	Token   string
+	Project string // "workspace/repo_slug"
+	PRID    string
+}
+
+func (p bitbucketProvider) FetchCommits(ctx context.Context) ([]Commit, error) {
+	return getBitbucketCommits(ctx, p.APIURL, p.Token, p.Project, p.PRID)
+}
+
+type giteaProvider struct {
+	APIURL  string
+	Token   string
+	Repo    string // "owner/repo"
+	PRIndex string
+}
+
+func (p giteaProvider) FetchCommits(ctx context.Context) ([]Commit, error) {
+	return getGiteaCommits(ctx, p.APIURL, p.Token, p.Repo, p.PRIndex)
+}
+
+type localProvider struct {
+	RepoPath   string
+	Range      string
+	SkipMerges bool
+}
+
+func (p localProvider) FetchCommits(_ context.Context) ([]Commit, error) {
+	return getLocalCommits(p.RepoPath, p.Range, p.SkipMerges)
+}
+
+func newProvider(repoEnv *gitEnv) (Provider, error) {
+	switch repoEnv.EnvName {
+	case GITHUB:
+		return githubProvider{Token: repoEnv.Token, Repo: repoEnv.ProjectID, SHA: repoEnv.PMRequestID}, nil
+	case GITLAB:
+		return gitlabProvider{URL: repoEnv.URL, Token: repoEnv.Token, Project: repoEnv.ProjectID, MR: repoEnv.PMRequestID}, nil
+	case BITBUCKET:
+		return bitbucketProvider{APIURL: repoEnv.URL, Token: repoEnv.Token, Project: repoEnv.ProjectID, PRID: repoEnv.PMRequestID}, nil
+	case GITEA:
+		return giteaProvider{APIURL: repoEnv.URL, Token: repoEnv.Token, Repo: repoEnv.ProjectID, PRIndex: repoEnv.PMRequestID}, nil
+	case LOCAL:
+		return localProvider{RepoPath: repoEnv.RepoPath, Range: repoEnv.Range, SkipMerges: repoEnv.SkipMerges}, nil
+	default:
+		return nil, fmt.Errorf("unrecognized git environment %s", repoEnv.EnvName)
+	}
+}
+
+// getCommits fetches the SHA and full message of every commit under review.
+func getCommits(ctx context.Context, repoEnv *gitEnv) ([]Commit, error) {
+	provider, err := newProvider(repoEnv)
+	if err != nil {
+		return nil, err
+	}
+
+	return provider.FetchCommits(ctx)
 }
 
 var ErrSubjectList = errors.New("subjects contain errors")
 
-func (c CommitPolicyConfig) CheckSubjectList(subjects []string) error {
-	errors := false
+// CheckSubjectList validates every commit's subject, body and trailers,
+// building a Report of whatever violations it finds and, under
+// FormatConventional, the strongest ReleaseImpact across the commits whose
+// subject is valid (a body or trailer violation doesn't exclude a commit).
+func (c CommitPolicyConfig) CheckSubjectList(commits []Commit) (*Report, ReleaseImpact, error) {
+	report := &Report{}
+	impact := ReleaseImpactNone
+
+	for _, commit := range commits {
+		parts := strings.SplitN(commit.Message, "\n", 2)
+		subject := strings.Trim(parts[0], "'")
+
+		body := ""
+		if len(parts) > 1 {
+			body = parts[1]
+		}
 
-	for _, subject := range subjects {
-		subject = strings.Trim(subject, "'")
-		if err := c.CheckSubject([]byte(subject)); err != nil {
+		parsed, err := c.CheckSubject([]byte(subject))
+		if err != nil {
 			log.Printf("%s, original subject message '%s'", err, subject)
 
-			errors = true
+			report.addError(subject, commit.SHA, err)
+
+			continue
+		}
+
+		if err := c.CheckBody(body); err != nil {
+			log.Printf("%s, commit subject '%s'", err, subject)
+
+			report.addError(subject, commit.SHA, err)
+		}
+
+		if err := c.CheckTrailers(body); err != nil {
+			log.Printf("%s, commit subject '%s'", err, subject)
+
+			report.addError(subject, commit.SHA, err)
+		}
+
+		if parsed != nil {
+			impact = maxReleaseImpact(impact, parsed.ReleaseImpact())
 		}
 	}
 
-	if errors {
-		return ErrSubjectList
+	if report.HasErrors() {
+		return report, impact, ErrSubjectList
 	}
 
-	return nil
+	return report, impact, nil
 }
 
-const requiredCmdlineArgs = 2
+func writeGithubOutput(name, value string) error {
+	outputPath := os.Getenv("GITHUB_OUTPUT")
+	if outputPath == "" {
+		return nil
+	}
 
-func main() {
-	var repoPath string
+	f, err := os.OpenFile(outputPath, os.O_APPEND|os.O_WRONLY|os.O_CREATE, 0o644)
+	if err != nil {
+		return fmt.Errorf("error opening GITHUB_OUTPUT file: %w", err)
+	}
+	defer f.Close()
 
-	if len(os.Args) < requiredCmdlineArgs {
-		repoPath = "."
-	} else {
-		repoPath = os.Args[1]
+	if _, err := fmt.Fprintf(f, "%s=%s\n", name, value); err != nil {
+		return fmt.Errorf("error writing GITHUB_OUTPUT file: %w", err)
+	}
+
+	return nil
+}
+
+func main() {
+	emitVersionBump := flag.Bool("emit-version-bump", false,
+		"compute and emit the semantic version bump (patch/minor/major) implied by the checked commits")
+	commitRange := flag.String("range", "",
+		"explicit commit range 'A..B' to check instead of auto-detecting the CI environment (implies the LOCAL backend)")
+	skipMergeCommits := flag.Bool("skip-merge-commits", false,
+		"skip merge commits when reading commit subjects from the local git history")
+	reportPath := flag.String("report", "",
+		"write a structured check report to this path, in the format selected by --format")
+	reportFormat := flag.String("format", "text",
+		"report format to write to --report: text, json or sarif")
+	suggest := flag.Bool("suggest", false,
+		"post a best-effort corrected subject back to the originating PR/MR for each failed commit")
+	dryRun := flag.Bool("dry-run", false,
+		"with --suggest, log the suggestions instead of posting them")
+	flag.Parse()
+
+	repoPath := "."
+	if args := flag.Args(); len(args) > 0 {
+		repoPath = args[0]
 	}
 
 	commitPolicy, err := LoadCommitPolicy(path.Join(repoPath, ".check-commit.yml"))
@@ -399,20 +986,45 @@ func main() {
 		log.Printf("WARNING: using empty configuration (i.e. no verification)")
 	}
 
-	gitEnv, err := readGitEnvironment()
+	gitEnv, err := readGitEnvironment(*commitRange)
 	if err != nil {
 		log.Fatalf("couldn't auto-detect running environment, please set GITHUB_REF and GITHUB_BASE_REF manually: %s", err)
 	}
 
-	subjects, err := getCommitSubjects(gitEnv)
+	gitEnv.RepoPath = repoPath
+	gitEnv.SkipMerges = *skipMergeCommits
+
+	commits, err := getCommits(context.Background(), gitEnv)
 	if err != nil {
-		log.Fatalf("error getting commit subjects: %s", err)
+		log.Fatalf("error getting commits: %s", err)
+	}
+
+	report, impact, err := commitPolicy.CheckSubjectList(commits)
+
+	if *reportPath != "" {
+		if writeErr := report.Write(*reportPath, *reportFormat); writeErr != nil {
+			log.Printf("warning: unable to write %s report to '%s': %s", *reportFormat, *reportPath, writeErr)
+		}
+	}
+
+	if *suggest {
+		if suggestErr := commitPolicy.PostSuggestions(context.Background(), gitEnv, report, *dryRun); suggestErr != nil {
+			log.Printf("warning: unable to post suggestions: %s", suggestErr)
+		}
 	}
 
-	if err := commitPolicy.CheckSubjectList(subjects); err != nil {
+	if err != nil {
 		log.Printf("encountered one or more commit message errors\n")
 		log.Fatalf("%s\n", commitPolicy.HelpText)
 	}
 
+	if *emitVersionBump {
+		log.Printf("computed version bump: %s\n", impact)
+
+		if err := writeGithubOutput("version-bump", string(impact)); err != nil {
+			log.Printf("warning: unable to emit version-bump output: %s", err)
+		}
+	}
+
 	log.Printf("check completed without errors\n")
 }
@@ -0,0 +1,123 @@
+package main
+
+import "testing"
+
+func conventionalPolicy() CommitPolicyConfig {
+	return CommitPolicyConfig{Format: FormatConventional}
+}
+
+func TestCheckSubjectConventional(t *testing.T) {
+	t.Parallel()
+
+	c := conventionalPolicy()
+
+	tests := []struct {
+		name       string
+		subject    string
+		wantErr    bool
+		wantImpact ReleaseImpact
+	}{
+		{
+			name:       "feature",
+			subject:    "feat(parser): support conventional commit headers",
+			wantErr:    false,
+			wantImpact: ReleaseImpactMinor,
+		},
+		{
+			name:       "fix without scope",
+			subject:    "fix: stop double counting release impact",
+			wantErr:    false,
+			wantImpact: ReleaseImpactPatch,
+		},
+		{
+			name:       "breaking via bang",
+			subject:    "feat(api)!: drop support for the legacy token format",
+			wantErr:    false,
+			wantImpact: ReleaseImpactMajor,
+		},
+		{
+			name:    "missing colon",
+			subject: "feat parser support conventional commit headers",
+			wantErr: true,
+		},
+		{
+			name:    "description too short",
+			subject: "fix: oops",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			parsed, err := c.CheckSubject([]byte(tt.subject))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("CheckSubject() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if tt.wantErr {
+				return
+			}
+
+			if impact := parsed.ReleaseImpact(); impact != tt.wantImpact {
+				t.Errorf("ReleaseImpact() = %s, want %s", impact, tt.wantImpact)
+			}
+		})
+	}
+}
+
+func TestParseConventionalSubjectBreakingFooter(t *testing.T) {
+	t.Parallel()
+
+	message := "refactor(auth): rework the token refresh flow\n\n" +
+		"BREAKING CHANGE: tokens issued before this change are no longer accepted"
+
+	parsed, err := parseConventionalSubject(message)
+	if err != nil {
+		t.Fatalf("parseConventionalSubject() error = %v", err)
+	}
+
+	if impact := parsed.ReleaseImpact(); impact != ReleaseImpactMajor {
+		t.Errorf("ReleaseImpact() = %s, want %s", impact, ReleaseImpactMajor)
+	}
+}
+
+func TestParseConventionalSubjectIgnoresBreakingChangeOutsideTrailerBlock(t *testing.T) {
+	t.Parallel()
+
+	message := "fix(auth): correct token refresh retry count\n\n" +
+		"For background, see how the old client handled this:\n" +
+		"BREAKING CHANGE: this line is just illustrative text in the body\n\n" +
+		"Signed-off-by: Jane Doe <jane@example.com>"
+
+	parsed, err := parseConventionalSubject(message)
+	if err != nil {
+		t.Fatalf("parseConventionalSubject() error = %v", err)
+	}
+
+	if impact := parsed.ReleaseImpact(); impact != ReleaseImpactPatch {
+		t.Errorf("ReleaseImpact() = %s, want %s (illustrative text isn't a real footer)", impact, ReleaseImpactPatch)
+	}
+}
+
+func TestCheckSubjectListConventionalAggregatesImpact(t *testing.T) {
+	t.Parallel()
+
+	c := conventionalPolicy()
+
+	commits := []Commit{
+		{SHA: "aaaa111", Message: "fix: correct off-by-one in subject word count"},
+		{SHA: "bbbb222", Message: "feat(cli): add emit-version-bump flag"},
+	}
+
+	_, impact, err := c.CheckSubjectList(commits)
+	if err != nil {
+		t.Fatalf("CheckSubjectList() error = %v", err)
+	}
+
+	if impact != ReleaseImpactMinor {
+		t.Errorf("CheckSubjectList() impact = %s, want %s", impact, ReleaseImpactMinor)
+	}
+}
@@ -100,7 +100,7 @@ func TestDifferentPolicy(t *testing.T) {
 		tt := tt
 		t.Run(tt.name, func(t *testing.T) {
 			t.Parallel()
-			if err := c.CheckSubject([]byte(tt.subject)); (err != nil) != tt.wantErr {
+			if _, err := c.CheckSubject([]byte(tt.subject)); (err != nil) != tt.wantErr {
 				t.Errorf("checkSubject() error = %v, wantErr %v", err, tt.wantErr)
 			}
 		})
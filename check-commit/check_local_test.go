@@ -0,0 +1,167 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+func TestResolveCommitRangeInvalidSpec(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		spec string
+	}{
+		{name: "no separator", spec: "onlyonerevision"},
+		{name: "empty from", spec: "..master"},
+		{name: "empty to", spec: "master.."},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			// resolveCommitRange rejects a malformed spec before it ever
+			// touches repo, so a nil *git.Repository is safe to pass here.
+			if _, _, err := resolveCommitRange(nil, tt.spec); err == nil {
+				t.Errorf("resolveCommitRange(%q) expected an error, got nil", tt.spec)
+			}
+		})
+	}
+}
+
+func TestGetLocalCommitsOpenFailure(t *testing.T) {
+	t.Parallel()
+
+	if _, err := getLocalCommits(filepath.Join(t.TempDir(), "does-not-exist"), "", false); err == nil {
+		t.Errorf("getLocalCommits() expected an error for a non-repository path")
+	}
+}
+
+var testSignature = object.Signature{Name: "tester", Email: "tester@example.com", When: time.Unix(1700000000, 0)}
+
+func initTestRepo(t *testing.T) (*git.Repository, string) {
+	t.Helper()
+
+	dir := t.TempDir()
+
+	repo, err := git.PlainInit(dir, false)
+	if err != nil {
+		t.Fatalf("git.PlainInit() error = %v", err)
+	}
+
+	return repo, dir
+}
+
+func commitFile(t *testing.T, repo *git.Repository, dir, name, message string) plumbing.Hash {
+	t.Helper()
+
+	wt, err := repo.Worktree()
+	if err != nil {
+		t.Fatalf("Worktree() error = %v", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(message), 0o644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	if _, err := wt.Add(name); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+
+	hash, err := wt.Commit(message, &git.CommitOptions{Author: &testSignature, Committer: &testSignature})
+	if err != nil {
+		t.Fatalf("Commit() error = %v", err)
+	}
+
+	return hash
+}
+
+// makeMergeCommit builds a merge commit object directly (go-git has no
+// plumbing-level merge command), reusing the first parent's tree since the test
+// only cares about NumParents(), not the merged content.
+func makeMergeCommit(t *testing.T, repo *git.Repository, message string, parents ...plumbing.Hash) plumbing.Hash {
+	t.Helper()
+
+	parentCommit, err := repo.CommitObject(parents[0])
+	if err != nil {
+		t.Fatalf("CommitObject() error = %v", err)
+	}
+
+	commit := &object.Commit{
+		Author:       testSignature,
+		Committer:    testSignature,
+		Message:      message,
+		TreeHash:     parentCommit.TreeHash,
+		ParentHashes: parents,
+	}
+
+	obj := repo.Storer.NewEncodedObject()
+	if err := commit.Encode(obj); err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+
+	hash, err := repo.Storer.SetEncodedObject(obj)
+	if err != nil {
+		t.Fatalf("SetEncodedObject() error = %v", err)
+	}
+
+	return hash
+}
+
+func TestGetLocalCommitsSkipMerges(t *testing.T) {
+	t.Parallel()
+
+	repo, dir := initTestRepo(t)
+
+	root := commitFile(t, repo, dir, "a.txt", "root commit")
+	onMain := commitFile(t, repo, dir, "b.txt", "commit on main")
+	merge := makeMergeCommit(t, repo, "merge commit", onMain, root)
+
+	rangeSpec := root.String() + ".." + merge.String()
+
+	withMerges, err := getLocalCommits(dir, rangeSpec, false)
+	if err != nil {
+		t.Fatalf("getLocalCommits() error = %v", err)
+	}
+
+	if len(withMerges) != 2 {
+		t.Fatalf("with merges: got %d commits, want 2: %+v", len(withMerges), withMerges)
+	}
+
+	withoutMerges, err := getLocalCommits(dir, rangeSpec, true)
+	if err != nil {
+		t.Fatalf("getLocalCommits() error = %v", err)
+	}
+
+	if len(withoutMerges) != 1 {
+		t.Fatalf("without merges: got %d commits, want 1: %+v", len(withoutMerges), withoutMerges)
+	}
+
+	if withoutMerges[0].Message != "commit on main" {
+		t.Errorf("without merges: got message %q, want %q", withoutMerges[0].Message, "commit on main")
+	}
+}
+
+func TestGetLocalCommitsNonAncestorRange(t *testing.T) {
+	t.Parallel()
+
+	repo, dir := initTestRepo(t)
+
+	root := commitFile(t, repo, dir, "a.txt", "root commit")
+	// child is a descendant of root, so it is NOT an ancestor of root: using
+	// it as the range's lower bound with root as the upper bound exercises
+	// exactly the non-ancestor case.
+	child := makeMergeCommit(t, repo, "child of root", root)
+
+	if _, err := getLocalCommits(dir, child.String()+".."+root.String(), false); err == nil {
+		t.Errorf("getLocalCommits() expected an error when the lower bound isn't an ancestor of the upper bound")
+	}
+}
@@ -0,0 +1,60 @@
+package main
+
+import "testing"
+
+func TestNewProviderSelection(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		env     gitEnv
+		want    Provider
+		wantErr bool
+	}{
+		{
+			name: "github",
+			env:  gitEnv{EnvName: GITHUB, Token: "t", ProjectID: "haproxytech/github-actions", PMRequestID: "sha"},
+			want: githubProvider{Token: "t", Repo: "haproxytech/github-actions", SHA: "sha"},
+		},
+		{
+			name: "bitbucket",
+			env:  gitEnv{EnvName: BITBUCKET, URL: "https://api.bitbucket.org/2.0", Token: "t", ProjectID: "ws/repo", PMRequestID: "1"},
+			want: bitbucketProvider{APIURL: "https://api.bitbucket.org/2.0", Token: "t", Project: "ws/repo", PRID: "1"},
+		},
+		{
+			name: "gitea",
+			env:  gitEnv{EnvName: GITEA, URL: "https://gitea.example.com/api/v1", Token: "t", ProjectID: "owner/repo", PMRequestID: "42"},
+			want: giteaProvider{APIURL: "https://gitea.example.com/api/v1", Token: "t", Repo: "owner/repo", PRIndex: "42"},
+		},
+		{
+			name: "local",
+			env:  gitEnv{EnvName: LOCAL, RepoPath: ".", Range: "a..b", SkipMerges: true},
+			want: localProvider{RepoPath: ".", Range: "a..b", SkipMerges: true},
+		},
+		{
+			name:    "unknown",
+			env:     gitEnv{EnvName: "Unknown"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := newProvider(&tt.env)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("newProvider() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if tt.wantErr {
+				return
+			}
+
+			if got != tt.want {
+				t.Errorf("newProvider() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,321 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// Stable rule IDs for each policy violation this tool can detect, used both
+// as the `RuleID` on a Finding and as a SARIF reportingDescriptor id so
+// findings keep the same identity across runs and report formats.
+const (
+	RuleUnknown            = "CC000"
+	RuleASCIIOnly          = "CC001"
+	RuleTagOrder           = "CC002"
+	RulePatchType          = "CC003"
+	RuleSubjectLength      = "CC004"
+	RuleDoubleSpaces       = "CC005"
+	RuleConventionalFormat = "CC006"
+	RuleMissingTrailer     = "CC007"
+	RuleMissingIssueRef    = "CC008"
+	RuleBodyWrap           = "CC009"
+)
+
+var ruleDescriptions = map[string]string{
+	RuleUnknown:            "unclassified commit policy violation",
+	RuleASCIIOnly:          "commit subject must only contain ASCII characters",
+	RuleTagOrder:           "commit subject tags must appear in the configured order",
+	RulePatchType:          "commit subject tag/severity must match a configured patch type",
+	RuleSubjectLength:      "commit subject word count and length must stay within the allowed bounds",
+	RuleDoubleSpaces:       "commit subject must not contain trailing or double spaces",
+	RuleConventionalFormat: "commit subject must follow the Conventional Commits header format",
+	RuleMissingTrailer:     "commit body must include a required trailer",
+	RuleMissingIssueRef:    "commit message must reference an issue matching the configured pattern",
+	RuleBodyWrap:           "commit body lines must not exceed the configured wrap width",
+}
+
+// noLocation marks a violation that isn't a position within the subject
+// (e.g. a missing trailer, which is a property of the body), as opposed to
+// location 0, which is a real offset at the very start of the subject.
+const noLocation = -1
+
+// ruleViolation tags an error with the stable rule ID it corresponds to and,
+// where known, the byte offset within the subject it was detected at, so
+// reporting code can recover both with errors.As without string-matching
+// messages.
+type ruleViolation struct {
+	RuleID   string
+	Location int
+	err      error
+}
+
+func newRuleViolation(ruleID string, err error) error {
+	return &ruleViolation{RuleID: ruleID, Location: noLocation, err: err}
+}
+
+// newRuleViolationAt is like newRuleViolation, but records the byte offset
+// within the subject the violation was found at.
+func newRuleViolationAt(ruleID string, location int, err error) error {
+	return &ruleViolation{RuleID: ruleID, Location: location, err: err}
+}
+
+func (e *ruleViolation) Error() string {
+	return e.err.Error()
+}
+
+func (e *ruleViolation) Unwrap() error {
+	return e.err
+}
+
+func ruleIDFromError(err error) string {
+	var rv *ruleViolation
+	if errors.As(err, &rv) {
+		return rv.RuleID
+	}
+
+	return RuleUnknown
+}
+
+func locationFromError(err error) int {
+	var rv *ruleViolation
+	if errors.As(err, &rv) {
+		return rv.Location
+	}
+
+	return noLocation
+}
+
+// Finding is a single commit policy violation, detailed enough to render as
+// a line in a text report, a JSON record, or a SARIF result. Location is the
+// byte offset within Subject the violation was detected at, or noLocation
+// when the violation isn't a subject position (e.g. a body/trailer rule).
+type Finding struct {
+	Subject  string `json:"subject"`
+	SHA      string `json:"sha,omitempty"`
+	RuleID   string `json:"ruleId"`
+	Location int    `json:"location"`
+	Message  string `json:"message"`
+	Severity string `json:"severity"`
+}
+
+// Report collects the findings gathered while checking a list of commits.
+type Report struct {
+	Findings []Finding `json:"findings"`
+}
+
+func (r *Report) addError(subject string, sha string, err error) {
+	r.Findings = append(r.Findings, Finding{
+		Subject:  subject,
+		SHA:      sha,
+		RuleID:   ruleIDFromError(err),
+		Location: locationFromError(err),
+		Message:  err.Error(),
+		Severity: "error",
+	})
+}
+
+// HasErrors reports whether the report contains at least one error-severity
+// finding.
+func (r *Report) HasErrors() bool {
+	for _, f := range r.Findings {
+		if f.Severity == "error" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// WriteText renders the report the way CheckSubjectList used to log it, one
+// line per finding.
+func (r *Report) WriteText(path string) error {
+	var buf []byte
+
+	for _, f := range r.Findings {
+		buf = append(buf, []byte(fmt.Sprintf("%s, original subject message '%s'\n", f.Message, f.Subject))...)
+	}
+
+	return writeReportFile(path, buf)
+}
+
+// WriteJSON renders the report as a JSON document.
+func (r *Report) WriteJSON(path string) error {
+	buf, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error marshalling report to json: %w", err)
+	}
+
+	return writeReportFile(path, append(buf, '\n'))
+}
+
+// sarifLog, sarifRun, sarifTool, sarifDriver, sarifRule, sarifResult and
+// sarifMessage are a minimal subset of the SARIF 2.1.0 object model, just
+// enough to drive github/codeql-action/upload-sarif.
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string       `json:"id"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations,omitempty"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+// sarifLocation, sarifPhysicalLocation, sarifArtifactLocation and sarifRegion
+// carry a finding's position. A commit subject isn't a file in the pull
+// request's diff, so artifactLocation.uri is a synthetic "commit:<sha>"
+// reference rather than a real repository path: this records which commit
+// and which offset within its subject a finding came from for any SARIF
+// consumer, but (unlike an annotation on a changed file) GitHub's
+// code-scanning UI won't render it as an inline PR diff annotation.
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           sarifRegion           `json:"region"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn,omitempty"`
+}
+
+// sarifFindingLocation builds the synthetic commit-subject location for a
+// finding, putting a startColumn on the region only when Location is a real
+// offset (see Finding.Location).
+func sarifFindingLocation(f Finding) sarifLocation {
+	sha := f.SHA
+	if sha == "" {
+		sha = "HEAD"
+	}
+
+	region := sarifRegion{StartLine: 1}
+	if f.Location >= 0 {
+		region.StartColumn = f.Location + 1
+	}
+
+	return sarifLocation{
+		PhysicalLocation: sarifPhysicalLocation{
+			ArtifactLocation: sarifArtifactLocation{URI: "commit:" + sha},
+			Region:           region,
+		},
+	}
+}
+
+func sarifLevel(severity string) string {
+	if severity == "error" {
+		return "error"
+	}
+
+	return "warning"
+}
+
+// WriteSARIF renders the report as a SARIF 2.1.0 log, with one
+// reportingDescriptor per rule ID referenced by at least one finding, so it
+// can be uploaded as GitHub code scanning results.
+func (r *Report) WriteSARIF(path string) error {
+	seenRules := map[string]bool{}
+
+	rules := []sarifRule{}
+	results := []sarifResult{}
+
+	for _, f := range r.Findings {
+		if !seenRules[f.RuleID] {
+			seenRules[f.RuleID] = true
+
+			rules = append(rules, sarifRule{
+				ID:               f.RuleID,
+				ShortDescription: sarifMessage{Text: ruleDescriptions[f.RuleID]},
+			})
+		}
+
+		results = append(results, sarifResult{
+			RuleID:    f.RuleID,
+			Level:     sarifLevel(f.Severity),
+			Message:   sarifMessage{Text: f.Message},
+			Locations: []sarifLocation{sarifFindingLocation(f)},
+		})
+	}
+
+	log := sarifLog{
+		Schema:  "https://raw.githubusercontent.com/oasis-tcs/sarif-spec/master/Schemata/sarif-schema-2.1.0.json",
+		Version: "2.1.0",
+		Runs: []sarifRun{
+			{
+				Tool: sarifTool{
+					Driver: sarifDriver{
+						Name:           "check-commit",
+						InformationURI: "https://github.com/haproxytech/github-actions",
+						Rules:          rules,
+					},
+				},
+				Results: results,
+			},
+		},
+	}
+
+	buf, err := json.MarshalIndent(log, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error marshalling report to sarif: %w", err)
+	}
+
+	return writeReportFile(path, append(buf, '\n'))
+}
+
+// Write renders the report in the given format ("text", "json" or "sarif")
+// to path.
+func (r *Report) Write(path string, format string) error {
+	switch format {
+	case "", "text":
+		return r.WriteText(path)
+	case "json":
+		return r.WriteJSON(path)
+	case "sarif":
+		return r.WriteSARIF(path)
+	default:
+		return fmt.Errorf("unsupported report format '%s'", format)
+	}
+}
+
+func writeReportFile(path string, data []byte) error {
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return fmt.Errorf("error writing report to '%s': %w", path, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReportWriteJSON(t *testing.T) {
+	t.Parallel()
+
+	c := CommitPolicyConfig{Format: FormatConventional}
+	report, _, err := c.CheckSubjectList([]Commit{{SHA: "cccc333", Message: "oops"}})
+	if err == nil {
+		t.Fatalf("CheckSubjectList() expected an error for an invalid subject")
+	}
+
+	if len(report.Findings) != 1 {
+		t.Fatalf("expected 1 finding, got %d", len(report.Findings))
+	}
+
+	if report.Findings[0].RuleID != RuleConventionalFormat {
+		t.Errorf("RuleID = %s, want %s", report.Findings[0].RuleID, RuleConventionalFormat)
+	}
+
+	if report.Findings[0].SHA != "cccc333" {
+		t.Errorf("SHA = %s, want %s", report.Findings[0].SHA, "cccc333")
+	}
+
+	out := filepath.Join(t.TempDir(), "report.json")
+	if err := report.WriteJSON(out); err != nil {
+		t.Fatalf("WriteJSON() error = %v", err)
+	}
+
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatalf("reading report: %v", err)
+	}
+
+	var decoded Report
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("decoding report json: %v", err)
+	}
+
+	if len(decoded.Findings) != 1 {
+		t.Errorf("decoded findings = %d, want 1", len(decoded.Findings))
+	}
+}
+
+func TestReportWriteSARIFIncludesRule(t *testing.T) {
+	t.Parallel()
+
+	report := &Report{Findings: []Finding{
+		{Subject: "oops", RuleID: RuleSubjectLength, Message: "subject too short", Severity: "error"},
+	}}
+
+	out := filepath.Join(t.TempDir(), "report.sarif")
+	if err := report.WriteSARIF(out); err != nil {
+		t.Fatalf("WriteSARIF() error = %v", err)
+	}
+
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatalf("reading report: %v", err)
+	}
+
+	var decoded sarifLog
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("decoding sarif log: %v", err)
+	}
+
+	if len(decoded.Runs) != 1 || len(decoded.Runs[0].Tool.Driver.Rules) != 1 {
+		t.Fatalf("expected exactly one rule in the driver, got %+v", decoded)
+	}
+
+	if decoded.Runs[0].Tool.Driver.Rules[0].ID != RuleSubjectLength {
+		t.Errorf("rule id = %s, want %s", decoded.Runs[0].Tool.Driver.Rules[0].ID, RuleSubjectLength)
+	}
+
+	if len(decoded.Runs[0].Results) != 1 || len(decoded.Runs[0].Results[0].Locations) != 1 {
+		t.Fatalf("expected exactly one location on the one result, got %+v", decoded.Runs[0].Results)
+	}
+}
+
+func TestAddErrorRecordsLocation(t *testing.T) {
+	t.Parallel()
+
+	report := &Report{}
+	report.addError("bad  subject", "deadbeef", newRuleViolationAt(RuleDoubleSpaces, 3, errors.New("double space")))
+	report.addError("bad subject body issue", "deadbeef", newRuleViolation(RuleMissingTrailer, errors.New("missing trailer")))
+
+	if got := report.Findings[0].Location; got != 3 {
+		t.Errorf("Location = %d, want 3", got)
+	}
+
+	if got := report.Findings[1].Location; got != noLocation {
+		t.Errorf("Location = %d, want %d (not a subject position)", got, noLocation)
+	}
+}
@@ -0,0 +1,235 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v35/github"
+	"github.com/xanzy/go-gitlab"
+	"golang.org/x/oauth2"
+)
+
+var tagSeverityRe = regexp.MustCompile(`^(?P<tag>[A-Za-z]+)(/(?P<severity>[A-Za-z]+))?: (?P<rest>.*)$`)
+
+// Suggest computes a best-effort corrected form of a failed subject:
+// trailing/double spaces are collapsed, a recognized tag/severity pair is
+// uppercased, and, failing to find a tag at all, the first configured patch
+// type is prepended as a starting point for the author to edit.
+func (c CommitPolicyConfig) Suggest(subject string) string {
+	collapsed := strings.Join(strings.Fields(subject), " ")
+
+	if m := tagSeverityRe.FindStringSubmatch(collapsed); m != nil {
+		tag := strings.ToUpper(m[tagSeverityRe.SubexpIndex("tag")])
+		severity := m[tagSeverityRe.SubexpIndex("severity")]
+		rest := m[tagSeverityRe.SubexpIndex("rest")]
+
+		prefix := tag
+		if severity != "" {
+			prefix += "/" + strings.ToUpper(severity)
+		}
+
+		return prefix + ": " + rest
+	}
+
+	if tag := c.firstKnownPatchType(); tag != "" {
+		return tag + ": " + collapsed
+	}
+
+	return collapsed
+}
+
+func (c CommitPolicyConfig) firstKnownPatchType() string {
+	for _, alt := range c.TagOrder {
+		for _, pType := range alt.PatchTypes {
+			if values := c.PatchTypes[pType].Values; len(values) > 0 {
+				return values[0]
+			}
+		}
+	}
+
+	return ""
+}
+
+// formatSuggestionComment renders a correction as a plain fenced code block.
+// GitHub and GitLab only render a "suggestion" fence as a one-click "commit
+// suggestion" inside a diff-anchored review comment (a file, line and commit
+// SHA in the pull request's diff); a commit subject isn't part of any file
+// diff, so there's nothing to anchor one to here. The corrected subject is
+// still posted as plain text for the author to copy into an amended commit.
+func formatSuggestionComment(suggestion string) string {
+	return fmt.Sprintf("Suggested commit subject (not auto-applicable, the subject isn't part of the diff):\n```\n%s\n```\n", suggestion)
+}
+
+const suggestMaxRetries = 5
+
+// suggestBaseDelay is a var rather than a const so tests can shrink it to
+// keep the backoff loop fast.
+var suggestBaseDelay = 2 * time.Second
+
+// postWithBackoff retries attempt with exponential backoff, honoring
+// GitHub's rate-limit/abuse-rate-limit errors and any Retry-After the forge
+// reports, so retrying against the API during a large PR doesn't burn quota.
+func postWithBackoff(ctx context.Context, attempt func() error) error {
+	delay := suggestBaseDelay
+
+	var lastErr error
+
+	for i := 0; i < suggestMaxRetries; i++ {
+		lastErr = attempt()
+		if lastErr == nil {
+			return nil
+		}
+
+		wait := rateLimitWait(lastErr)
+		if wait <= 0 {
+			wait = delay
+		}
+
+		log.Printf("rate limited while posting suggestion, backing off for %s (attempt %d/%d): %s",
+			wait, i+1, suggestMaxRetries, lastErr)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(wait):
+		}
+
+		delay *= 2
+	}
+
+	return fmt.Errorf("giving up posting suggestion after %d attempts: %w", suggestMaxRetries, lastErr)
+}
+
+func rateLimitWait(err error) time.Duration {
+	var rateLimitErr *github.RateLimitError
+	if errors.As(err, &rateLimitErr) {
+		return time.Until(rateLimitErr.Rate.Reset.Time)
+	}
+
+	var abuseErr *github.AbuseRateLimitError
+	if errors.As(err, &abuseErr) && abuseErr.RetryAfter != nil {
+		return *abuseErr.RetryAfter
+	}
+
+	var gitlabErr *gitlab.ErrorResponse
+	if errors.As(err, &gitlabErr) && gitlabErr.Response != nil && gitlabErr.Response.StatusCode == http.StatusTooManyRequests {
+		if ra := gitlabErr.Response.Header.Get("Retry-After"); ra != "" {
+			if secs, convErr := strconv.Atoi(ra); convErr == nil {
+				return time.Duration(secs) * time.Second
+			}
+		}
+	}
+
+	return 0
+}
+
+// PostSuggestions posts a suggested correction for each finding back to the
+// originating PR/MR, as a plain GitHub issue comment or GitLab MR note.
+// These land on the PR conversation rather than as an inline, one-click
+// "commit suggestion": that GitHub/GitLab affordance only exists for
+// diff-anchored review comments (a file, line and commit SHA in the PR's
+// diff), and a commit subject has none of those. It is a no-op on forges
+// this tool doesn't yet know how to comment on.
+func (c CommitPolicyConfig) PostSuggestions(ctx context.Context, repoEnv *gitEnv, report *Report, dryRun bool) error {
+	switch repoEnv.EnvName {
+	case GITHUB:
+		return c.postGithubSuggestions(ctx, repoEnv, report, dryRun)
+	case GITLAB:
+		return c.postGitlabSuggestions(ctx, repoEnv, report, dryRun)
+	default:
+		log.Printf("--suggest is not supported for the %s environment, skipping", repoEnv.EnvName)
+
+		return nil
+	}
+}
+
+func (c CommitPolicyConfig) postGithubSuggestions(ctx context.Context, repoEnv *gitEnv, report *Report, dryRun bool) error {
+	ts := oauth2.StaticTokenSource(&oauth2.Token{AccessToken: repoEnv.Token})
+	tc := oauth2.NewClient(ctx, ts)
+	githubClient := github.NewClient(tc)
+
+	repoSlice := strings.SplitN(repoEnv.ProjectID, "/", 2)
+
+	prs, _, err := githubClient.PullRequests.ListPullRequestsWithCommit(ctx, repoSlice[0], repoSlice[1], repoEnv.PMRequestID, &github.PullRequestListOptions{})
+	if err != nil {
+		return fmt.Errorf("error resolving pr for suggestions: %w", err)
+	}
+
+	if len(prs) == 0 {
+		log.Printf("no pull request found for %s, skipping suggestions", repoEnv.PMRequestID)
+
+		return nil
+	}
+
+	prNo := prs[0].GetNumber()
+
+	for _, f := range report.Findings {
+		suggestion := c.Suggest(f.Subject)
+		if suggestion == f.Subject {
+			continue
+		}
+
+		body := formatSuggestionComment(suggestion)
+
+		if dryRun {
+			log.Printf("[dry-run] would comment on %s/%s#%d:\n%s", repoSlice[0], repoSlice[1], prNo, body)
+
+			continue
+		}
+
+		if err := postWithBackoff(ctx, func() error {
+			_, _, err := githubClient.Issues.CreateComment(ctx, repoSlice[0], repoSlice[1], prNo, &github.IssueComment{Body: &body})
+
+			return err
+		}); err != nil {
+			return fmt.Errorf("error posting github suggestion: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (c CommitPolicyConfig) postGitlabSuggestions(ctx context.Context, repoEnv *gitEnv, report *Report, dryRun bool) error {
+	gitlabClient, err := gitlab.NewClient(repoEnv.Token, gitlab.WithBaseURL(repoEnv.URL))
+	if err != nil {
+		return fmt.Errorf("error creating gitlab client: %w", err)
+	}
+
+	mrID, err := strconv.Atoi(repoEnv.PMRequestID)
+	if err != nil {
+		return fmt.Errorf("invalid merge request id %s: %w", repoEnv.PMRequestID, err)
+	}
+
+	for _, f := range report.Findings {
+		suggestion := c.Suggest(f.Subject)
+		if suggestion == f.Subject {
+			continue
+		}
+
+		body := formatSuggestionComment(suggestion)
+
+		if dryRun {
+			log.Printf("[dry-run] would comment on %s!%d:\n%s", repoEnv.ProjectID, mrID, body)
+
+			continue
+		}
+
+		if err := postWithBackoff(ctx, func() error {
+			_, _, err := gitlabClient.Notes.CreateMergeRequestNote(
+				repoEnv.ProjectID, mrID, &gitlab.CreateMergeRequestNoteOptions{Body: &body})
+
+			return err
+		}); err != nil {
+			return fmt.Errorf("error posting gitlab suggestion: %w", err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v35/github"
+	"github.com/xanzy/go-gitlab"
+)
+
+func TestSuggest(t *testing.T) {
+	t.Parallel()
+
+	policy := CommitPolicyConfig{
+		TagOrder: []tagAlternativesT{
+			{PatchTypes: []string{"type"}},
+		},
+		PatchTypes: map[string]patchTypeT{
+			"type": {Values: []string{"BUG"}},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		subject string
+		want    string
+	}{
+		{
+			name:    "lowercase tag and severity are uppercased",
+			subject: "bug/minor: fix the thing",
+			want:    "BUG/MINOR: fix the thing",
+		},
+		{
+			name:    "lowercase tag without severity is uppercased",
+			subject: "bug: fix the thing",
+			want:    "BUG: fix the thing",
+		},
+		{
+			name:    "no recognizable tag falls back to first configured patch type",
+			subject: "fix the thing",
+			want:    "BUG: fix the thing",
+		},
+		{
+			name:    "double and trailing spaces are collapsed",
+			subject: "BUG:  fix   the thing  ",
+			want:    "BUG: fix the thing",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := policy.Suggest(tt.subject); got != tt.want {
+				t.Errorf("Suggest(%q) = %q, want %q", tt.subject, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestPostWithBackoffGivesUpAfterMaxRetries doesn't mark itself t.Parallel:
+// it temporarily shrinks the package-level suggestBaseDelay, which would
+// race with any other test reading or writing it concurrently.
+func TestPostWithBackoffGivesUpAfterMaxRetries(t *testing.T) {
+	originalDelay := suggestBaseDelay
+	suggestBaseDelay = time.Millisecond
+	t.Cleanup(func() { suggestBaseDelay = originalDelay })
+
+	wantErr := errors.New("not a rate limit error")
+
+	attempts := 0
+	err := postWithBackoff(context.Background(), func() error {
+		attempts++
+
+		return wantErr
+	})
+
+	if attempts != suggestMaxRetries {
+		t.Errorf("attempts = %d, want %d", attempts, suggestMaxRetries)
+	}
+
+	if err == nil || !errors.Is(err, wantErr) {
+		t.Errorf("postWithBackoff() error = %v, want it to wrap %v", err, wantErr)
+	}
+}
+
+func TestPostWithBackoffStopsOnSuccess(t *testing.T) {
+	originalDelay := suggestBaseDelay
+	suggestBaseDelay = time.Millisecond
+	t.Cleanup(func() { suggestBaseDelay = originalDelay })
+
+	attempts := 0
+	err := postWithBackoff(context.Background(), func() error {
+		attempts++
+		if attempts < 2 {
+			return errors.New("transient")
+		}
+
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("postWithBackoff() error = %v", err)
+	}
+
+	if attempts != 2 {
+		t.Errorf("attempts = %d, want 2", attempts)
+	}
+}
+
+func TestRateLimitWaitGithubRateLimitError(t *testing.T) {
+	t.Parallel()
+
+	reset := time.Now().Add(5 * time.Minute)
+	err := &github.RateLimitError{Rate: github.Rate{Reset: github.Timestamp{Time: reset}}}
+
+	wait := rateLimitWait(err)
+	if wait <= 4*time.Minute || wait > 5*time.Minute {
+		t.Errorf("rateLimitWait() = %s, want roughly 5m", wait)
+	}
+}
+
+func TestRateLimitWaitGithubAbuseRateLimitError(t *testing.T) {
+	t.Parallel()
+
+	retryAfter := 30 * time.Second
+	err := &github.AbuseRateLimitError{RetryAfter: &retryAfter}
+
+	if wait := rateLimitWait(err); wait != retryAfter {
+		t.Errorf("rateLimitWait() = %s, want %s", wait, retryAfter)
+	}
+}
+
+func TestRateLimitWaitGitlabRetryAfter(t *testing.T) {
+	t.Parallel()
+
+	err := &gitlab.ErrorResponse{
+		Response: &http.Response{
+			StatusCode: http.StatusTooManyRequests,
+			Header:     http.Header{"Retry-After": []string{"7"}},
+		},
+	}
+
+	if wait := rateLimitWait(err); wait != 7*time.Second {
+		t.Errorf("rateLimitWait() = %s, want 7s", wait)
+	}
+}
+
+func TestRateLimitWaitUnrelatedError(t *testing.T) {
+	t.Parallel()
+
+	if wait := rateLimitWait(errors.New("boring error")); wait != 0 {
+		t.Errorf("rateLimitWait() = %s, want 0", wait)
+	}
+}